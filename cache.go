@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SegmentCache stores extracted WAV segments and their transcription
+// results on disk, keyed by a hash of everything that can change the
+// output: source audio content, segment boundaries, model, prompt, and
+// whisper-cli flags. Reprocessing the same inputs - common during prompt
+// tuning, or re-running on a long recording - then skips both ffmpeg
+// extraction and whisper inference for any segment whose key hits.
+type SegmentCache struct {
+	Dir string
+
+	mu     sync.Mutex
+	Hits   int
+	Misses int
+}
+
+// NewSegmentCache creates a cache rooted at dir, creating it if necessary.
+func NewSegmentCache(dir string) (*SegmentCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	return &SegmentCache{Dir: dir}, nil
+}
+
+// SegmentCacheKey computes the content-addressed key for one segment's
+// extraction and transcription: md5(inputHash || start || end || model ||
+// prompt || flags).
+func SegmentCacheKey(inputHash string, start, end float64, modelPath, initialPrompt string, flags []string) string {
+	h := md5.New()
+	fmt.Fprintf(h, "%s|%.3f|%.3f|%s|%s|%s", inputHash, start, end, modelPath, initialPrompt, strings.Join(flags, " "))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *SegmentCache) wavPath(key string) string {
+	return filepath.Join(c.Dir, key+".wav")
+}
+
+func (c *SegmentCache) resultPath(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// cachedResult is the JSON shape stored alongside each cached segment.
+type cachedResult struct {
+	Text            string           `json:"text"`
+	VerboseSegments []VerboseSegment `json:"verbose_segments,omitempty"`
+}
+
+// FetchWAV copies the cached extraction for key to destPath. ok reports
+// whether the cache held an entry for key.
+func (c *SegmentCache) FetchWAV(key, destPath string) (ok bool) {
+	data, err := os.ReadFile(c.wavPath(key))
+	if err != nil {
+		return false
+	}
+	return os.WriteFile(destPath, data, 0644) == nil
+}
+
+// StoreWAV caches the extracted segment at srcPath under key.
+func (c *SegmentCache) StoreWAV(key, srcPath string) error {
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.wavPath(key), data, 0644)
+}
+
+// FetchResult loads a cached transcription result for key, if present.
+func (c *SegmentCache) FetchResult(key string) (cachedResult, bool) {
+	data, err := os.ReadFile(c.resultPath(key))
+	if err != nil {
+		return cachedResult{}, false
+	}
+	var result cachedResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return cachedResult{}, false
+	}
+	return result, true
+}
+
+// StoreResult caches a transcription result under key.
+func (c *SegmentCache) StoreResult(key string, result cachedResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.resultPath(key), data, 0644)
+}
+
+// RecordHit/RecordMiss track cache effectiveness across the run for the
+// final summary.
+func (c *SegmentCache) RecordHit() {
+	c.mu.Lock()
+	c.Hits++
+	c.mu.Unlock()
+}
+
+func (c *SegmentCache) RecordMiss() {
+	c.mu.Lock()
+	c.Misses++
+	c.mu.Unlock()
+}
+
+// Summary returns a human-readable hit/miss line for the run summary.
+func (c *SegmentCache) Summary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fmt.Sprintf("cache hits: %d, misses: %d", c.Hits, c.Misses)
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestSegmentCacheKey(t *testing.T) {
+	base := func() string {
+		return SegmentCacheKey("inputhash", 1.0, 2.5, "model.bin", "prompt", []string{"-l", "ja"})
+	}
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"base", base()},
+		{"different input hash", SegmentCacheKey("other", 1.0, 2.5, "model.bin", "prompt", []string{"-l", "ja"})},
+		{"different start", SegmentCacheKey("inputhash", 1.1, 2.5, "model.bin", "prompt", []string{"-l", "ja"})},
+		{"different end", SegmentCacheKey("inputhash", 1.0, 2.6, "model.bin", "prompt", []string{"-l", "ja"})},
+		{"different model", SegmentCacheKey("inputhash", 1.0, 2.5, "other.bin", "prompt", []string{"-l", "ja"})},
+		{"different prompt", SegmentCacheKey("inputhash", 1.0, 2.5, "model.bin", "other", []string{"-l", "ja"})},
+		{"different flags", SegmentCacheKey("inputhash", 1.0, 2.5, "model.bin", "prompt", []string{"-l", "en"})},
+		{"output format flag appended", SegmentCacheKey("inputhash", 1.0, 2.5, "model.bin", "prompt", []string{"-l", "ja", "--output-format", "verbose_json"})},
+	}
+
+	seen := map[string]string{"base": base()}
+	for _, tt := range tests[1:] {
+		if tt.key == seen["base"] {
+			t.Errorf("%s: expected a different key than base, got the same one", tt.name)
+		}
+	}
+
+	if base() != base() {
+		t.Error("SegmentCacheKey should be deterministic for identical inputs")
+	}
+}
+
+func TestSegmentCacheKeyFlagOrderMatters(t *testing.T) {
+	a := SegmentCacheKey("inputhash", 0, 1, "model.bin", "prompt", []string{"-l", "ja"})
+	b := SegmentCacheKey("inputhash", 0, 1, "model.bin", "prompt", []string{"ja", "-l"})
+	if a == b {
+		t.Error("expected flag order to affect the cache key since flags are joined positionally")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+// PortAudioCapture implements AudioCapture on top of PortAudio, opening the
+// system's default input device at streamSampleRate mono.
+type PortAudioCapture struct {
+	stream *portaudio.Stream
+	buf    []int16
+}
+
+// NewPortAudioCapture initializes PortAudio and prepares (but does not yet
+// open) a mono 16kHz input stream.
+func NewPortAudioCapture() (*PortAudioCapture, error) {
+	if err := portaudio.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize portaudio: %v", err)
+	}
+	return &PortAudioCapture{}, nil
+}
+
+// Start opens the default input device and begins streaming.
+func (p *PortAudioCapture) Start() error {
+	p.buf = make([]int16, 480) // 30ms @ 16kHz, matches defaultStreamConfig.FrameSize
+	stream, err := portaudio.OpenDefaultStream(1, 0, float64(streamSampleRate), len(p.buf), p.buf)
+	if err != nil {
+		return fmt.Errorf("failed to open portaudio input stream: %v", err)
+	}
+	if err := stream.Start(); err != nil {
+		return fmt.Errorf("failed to start portaudio input stream: %v", err)
+	}
+	p.stream = stream
+	return nil
+}
+
+// Read blocks until a frame is captured and copies it into buf.
+func (p *PortAudioCapture) Read(buf []int16) (int, error) {
+	if err := p.stream.Read(); err != nil {
+		return 0, fmt.Errorf("portaudio read failed: %v", err)
+	}
+	n := copy(buf, p.buf)
+	return n, nil
+}
+
+// Stop closes the stream and terminates PortAudio.
+func (p *PortAudioCapture) Stop() error {
+	if p.stream == nil {
+		return nil
+	}
+	if err := p.stream.Stop(); err != nil {
+		return err
+	}
+	if err := p.stream.Close(); err != nil {
+		return err
+	}
+	return portaudio.Terminate()
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -21,13 +23,18 @@ type AudioSegment struct {
 	Filename  string  `json:"filename"`
 	FilePath  string  `json:"file_path"`
 	Duration  float64 `json:"duration"`
+	// PriorText is an optional hint, e.g. from an existing subtitle cue,
+	// passed to whisper-cli via --prompt to improve recognition of
+	// recurring names in this segment.
+	PriorText string `json:"prior_text,omitempty"`
 }
 
 // TranscriptionResult represents the result from Python Whisper
 type TranscriptionResult struct {
-	Segment AudioSegment `json:"segment"`
-	Text    string       `json:"text"`
-	Error   string       `json:"error,omitempty"`
+	Segment         AudioSegment     `json:"segment"`
+	Text            string           `json:"text"`
+	Error           string           `json:"error,omitempty"`
+	VerboseSegments []VerboseSegment `json:"verbose_segments,omitempty"`
 }
 
 // AudioAnalyzer handles the entire audio processing pipeline
@@ -38,7 +45,16 @@ type AudioAnalyzer struct {
 	SilenceThreshold   string // ffmpegの無音検出閾値
 	SilenceDuration    string // 無音継続時間
 	MinSegmentDuration float64
-	MaxSegmentDuration float64 // 最大セグメント長（秒）
+	MaxSegmentDuration float64      // 最大セグメント長（秒）
+	OutputFormat       OutputFormat // txt, json, verbose_json, srt, vtt
+	VAD                VoiceActivityDetector
+	ModelPath          string   // whisper-cli.exeに渡すモデルファイル
+	WhisperFlags       []string // whisper-cli.exeに渡す追加フラグ（キャッシュキーにも使う）
+	Cache              *SegmentCache
+
+	hashOnce sync.Once
+	hashVal  string
+	hashErr  error
 }
 
 // NewAudioAnalyzer creates a new AudioAnalyzer instance
@@ -54,52 +70,66 @@ func NewAudioAnalyzer(inputFile, outputDir string) *AudioAnalyzer {
 
 	fmt.Printf("Detected %d CPU cores, using %d workers\n", numCPU, maxWorkers)
 
+	cache, err := NewSegmentCache(filepath.Join(outputDir, ".cache"))
+	if err != nil {
+		log.Printf("segment cache disabled: %v", err)
+	}
+
+	silenceThreshold := "-30dB"
+	silenceDuration := "5"
+
 	return &AudioAnalyzer{
 		InputFile:          inputFile,
 		OutputDir:          outputDir,
 		MaxWorkers:         maxWorkers,
-		SilenceThreshold:   "-30dB",
-		SilenceDuration:    "5",
+		SilenceThreshold:   silenceThreshold,
+		SilenceDuration:    silenceDuration,
 		MinSegmentDuration: 30.0,
 		MaxSegmentDuration: 60.0,
+		OutputFormat:       FormatText,
+		VAD:                FFmpegThresholdVAD{Threshold: silenceThreshold, Duration: silenceDuration},
+		ModelPath:          "./whisper.cpp/models/ggml-large-v3.bin",
+		WhisperFlags:       []string{"-l", "ja", "-otxt", "-tdrz", "-sns", "--suppress-nst"},
+		Cache:              cache,
 	}
 }
 
-// DetectSilence uses ffmpeg to detect silence periods in audio
-func (a *AudioAnalyzer) DetectSilence() ([]float64, error) {
-	cmd := exec.Command("ffmpeg",
-		"-i", a.InputFile,
-		"-af", fmt.Sprintf("silencedetect=noise=%s:duration=%s", a.SilenceThreshold, a.SilenceDuration),
-		"-f", "null", "-")
+// inputFileHash returns the md5 hash of the input file's contents, computed
+// once and memoized for the lifetime of the analyzer.
+func (a *AudioAnalyzer) inputFileHash() (string, error) {
+	a.hashOnce.Do(func() {
+		data, err := os.ReadFile(a.InputFile)
+		if err != nil {
+			a.hashErr = fmt.Errorf("failed to hash input file: %v", err)
+			return
+		}
+		sum := md5.Sum(data)
+		a.hashVal = hex.EncodeToString(sum[:])
+	})
+	return a.hashVal, a.hashErr
+}
 
-	output, err := cmd.CombinedOutput()
+// segmentCacheKey computes the cache key for a segment given the prompt
+// that will actually be sent to whisper-cli for it. OutputFormat is folded
+// in alongside WhisperFlags because it conditionally appends
+// --output-json-full (see transcribeCpp) without changing WhisperFlags
+// itself, so a txt run and a verbose_json run must not collide.
+func (a *AudioAnalyzer) segmentCacheKey(segment AudioSegment, prompt string) (string, error) {
+	inputHash, err := a.inputFileHash()
 	if err != nil {
-		return nil, fmt.Errorf("ffmpeg silence detection failed: %v", err)
+		return "", err
 	}
-
-	return a.parseSilenceOutput(string(output))
+	flags := append(append([]string{}, a.WhisperFlags...), "--output-format", string(a.OutputFormat))
+	return SegmentCacheKey(inputHash, segment.StartTime, segment.EndTime, a.ModelPath, prompt, flags), nil
 }
 
-// parseSilenceOutput parses ffmpeg silence detection output
-func (a *AudioAnalyzer) parseSilenceOutput(output string) ([]float64, error) {
-	var silencePoints []float64
-	lines := strings.Split(output, "\n")
-
-	for _, line := range lines {
-		if strings.Contains(line, "silence_end") {
-			parts := strings.Fields(line)
-			for i, part := range parts {
-				if part == "silence_end:" && i+1 < len(parts) {
-					timeStr := strings.TrimSpace(parts[i+1])
-					if time, err := strconv.ParseFloat(timeStr, 64); err == nil {
-						silencePoints = append(silencePoints, time)
-					}
-				}
-			}
-		}
-	}
-
-	return silencePoints, nil
+// NewAudioAnalyzerWithVAD creates an AudioAnalyzer that segments audio using
+// the given VoiceActivityDetector instead of the default ffmpeg-threshold
+// detector, e.g. a NeuralVAD for quiet speech that silencedetect misses.
+func NewAudioAnalyzerWithVAD(inputFile, outputDir string, vad VoiceActivityDetector) *AudioAnalyzer {
+	a := NewAudioAnalyzer(inputFile, outputDir)
+	a.VAD = vad
+	return a
 }
 
 // GetAudioDuration gets the total duration of the audio file
@@ -124,42 +154,50 @@ func (a *AudioAnalyzer) GetAudioDuration() (float64, error) {
 	return duration, nil
 }
 
-// CreateSegments creates audio segments based on silence detection
+// CreateSegments creates audio segments from the configured VAD's speech
+// regions.
 func (a *AudioAnalyzer) CreateSegments() ([]AudioSegment, error) {
-	// 無音区間を検出
-	silencePoints, err := a.DetectSilence()
+	// 発話区間を検出
+	regions, err := a.VAD.DetectSpeechRegions(a.InputFile)
 	if err != nil {
 		return nil, err
 	}
 
-	// 音声の総時間を取得
-	totalDuration, err := a.GetAudioDuration()
-	if err != nil {
-		return nil, err
-	}
+	// 隣接する発話区間を最小セグメント長を満たすようにマージ
+	segments := a.mergeSpeechRegions(regions)
+
+	// 長すぎるセグメントを分割
+	segments = a.splitLongSegments(segments)
+
+	return segments, nil
+}
 
-	// セグメントを作成
+// mergeSpeechRegions merges adjacent speech regions (bridging the
+// non-speech gaps between them) until each merged span satisfies
+// MinSegmentDuration. splitLongSegments is left to enforce
+// MaxSegmentDuration afterwards.
+func (a *AudioAnalyzer) mergeSpeechRegions(regions []SpeechRegion) []AudioSegment {
 	var segments []AudioSegment
-	currentStart := 0.0
+	if len(regions) == 0 {
+		return segments
+	}
 
-	for _, silenceEnd := range silencePoints {
-		if silenceEnd-currentStart >= 10 { // 最小3秒のセグメント
-			segment := a.createSegment(currentStart, silenceEnd, len(segments))
-			segments = append(segments, segment)
-			currentStart = silenceEnd
+	start := regions[0].Start
+	end := regions[0].End
+
+	for _, r := range regions[1:] {
+		end = r.End
+		if end-start >= a.MinSegmentDuration {
+			segments = append(segments, a.createSegment(start, end, len(segments)))
+			start = r.End
 		}
 	}
 
-	// 最後のセグメント
-	if totalDuration-currentStart >= 10 {
-		segment := a.createSegment(currentStart, totalDuration, len(segments))
-		segments = append(segments, segment)
+	if end-start >= a.MinSegmentDuration {
+		segments = append(segments, a.createSegment(start, end, len(segments)))
 	}
 
-	// 長すぎるセグメントを分割
-	segments = a.splitLongSegments(segments)
-
-	return segments, nil
+	return segments
 }
 
 // createSegment creates a single audio segment
@@ -196,6 +234,7 @@ func (a *AudioAnalyzer) splitLongSegments(segments []AudioSegment) []AudioSegmen
 				}
 
 				splitSegment := a.createSegment(start, end, len(result))
+				splitSegment.PriorText = segment.PriorText
 				result = append(result, splitSegment)
 			}
 		}
@@ -232,8 +271,22 @@ func (a *AudioAnalyzer) ExtractAudioSegments(segments []AudioSegment) error {
 	return nil
 }
 
-// extractSingleSegment extracts a single audio segment
+// extractSingleSegment extracts a single audio segment, serving it from the
+// segment cache when the same input/boundaries/model/prompt/flags have
+// already been extracted.
 func (a *AudioAnalyzer) extractSingleSegment(segment AudioSegment) error {
+	var cacheKey string
+	if a.Cache != nil {
+		key, err := a.segmentCacheKey(segment, a.promptForSegment(segment))
+		if err == nil {
+			cacheKey = key
+			if a.Cache.FetchWAV(cacheKey, segment.FilePath) {
+				a.Cache.RecordHit()
+				return nil
+			}
+		}
+	}
+
 	cmd := exec.Command("ffmpeg",
 		"-i", a.InputFile,
 		"-ss", fmt.Sprintf("%.3f", segment.StartTime),
@@ -246,6 +299,13 @@ func (a *AudioAnalyzer) extractSingleSegment(segment AudioSegment) error {
 		return fmt.Errorf("ffmpeg extraction failed: %v", err)
 	}
 
+	if cacheKey != "" {
+		a.Cache.RecordMiss()
+		if err := a.Cache.StoreWAV(cacheKey, segment.FilePath); err != nil {
+			log.Printf("failed to cache extracted segment %s: %v", segment.Filename, err)
+		}
+	}
+
 	return nil
 }
 
@@ -262,7 +322,7 @@ func (a *AudioAnalyzer) ProcessWithCpp(segments []AudioSegment) ([]Transcription
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			text, err := a.transcribeCpp(seg)
+			text, verboseSegments, err := a.transcribeCppVerbose(seg)
 			result := TranscriptionResult{
 				Segment: seg,
 			}
@@ -271,6 +331,7 @@ func (a *AudioAnalyzer) ProcessWithCpp(segments []AudioSegment) ([]Transcription
 				result.Error = err.Error()
 			} else {
 				result.Text = text
+				result.VerboseSegments = verboseSegments
 			}
 
 			results[index] = result
@@ -287,6 +348,24 @@ func (a *AudioAnalyzer) ProcessWithCpp(segments []AudioSegment) ([]Transcription
 	return results, nil
 }
 
+// defaultInitialPrompt primes whisper-cli with the recurring proper nouns
+// in this dataset when a segment has no subtitle-derived PriorText.
+const defaultInitialPrompt = `この音声は大学のサークル活動に関する会話です。
+				内容には「理科大（りかだい）」または「理大（りだい）」という大学名が登場します。
+				また、「理大祭（りだいさい）」というイベント名が含まれる場合があります。
+				会話は自然な日本語で行われており、学生同士のカジュアルなやり取りが含まれます。
+				固有名詞（大学名・イベント名など）は正確に認識してください。
+				日本語の音声の認識を行います`
+
+// promptForSegment returns the --prompt value for a segment: its
+// subtitle-derived PriorText if present, otherwise the dataset default.
+func (a *AudioAnalyzer) promptForSegment(segment AudioSegment) string {
+	if segment.PriorText != "" {
+		return segment.PriorText
+	}
+	return defaultInitialPrompt
+}
+
 func (a *AudioAnalyzer) transcribeCpp(segment AudioSegment) (string, error) {
 	// PythonスクリプトにJSONで音声ファイル情報を渡す
 	filepath := segment.FilePath
@@ -301,14 +380,16 @@ func (a *AudioAnalyzer) transcribeCpp(segment AudioSegment) (string, error) {
 
 	secondsStr := fmt.Sprintf("%.0f", seconds*1000) // convert seconds to milliseconds and string
 
-	initialPrompt := `この音声は大学のサークル活動に関する会話です。
-					内容には「理科大（りかだい）」または「理大（りだい）」という大学名が登場します。
-					また、「理大祭（りだいさい）」というイベント名が含まれる場合があります。
-					会話は自然な日本語で行われており、学生同士のカジュアルなやり取りが含まれます。
-					固有名詞（大学名・イベント名など）は正確に認識してください。
-					日本語の音声の認識を行います`
+	initialPrompt := a.promptForSegment(segment)
 
-	cmd := exec.Command("./whisper.cpp/build/bin/Release/whisper-cli.exe", "-m", "./whisper.cpp/models/ggml-large-v3.bin", "-f", "./"+strings.ReplaceAll(filepath, "\\", "/"), "-l", "ja", "--vad-min-speech-duration-ms", secondsStr, "-otxt", "-tdrz", "-sns", "--suppress-nst", "--prompt", initialPrompt)
+	args := []string{"-m", a.ModelPath, "-f", "./" + strings.ReplaceAll(filepath, "\\", "/")}
+	args = append(args, a.WhisperFlags...)
+	args = append(args, "--vad-min-speech-duration-ms", secondsStr, "--prompt", initialPrompt)
+	if a.OutputFormat == FormatVerboseJSON {
+		args = append(args, "--output-json-full")
+	}
+
+	cmd := exec.Command("./whisper.cpp/build/bin/Release/whisper-cli.exe", args...)
 	output, err := cmd.CombinedOutput()
 	fmt.Printf("C++ whisper output: %s\n", string(output))
 	if err != nil {
@@ -319,6 +400,46 @@ func (a *AudioAnalyzer) transcribeCpp(segment AudioSegment) (string, error) {
 	return result, nil
 }
 
+// transcribeCppVerbose wraps transcribeCpp and additionally parses
+// whisper-cli.exe's timestamped output (and, for verbose_json, its
+// --output-json-full sidecar) into OpenAI-shaped VerboseSegments. A cached
+// result for the same segment/model/prompt/flags skips whisper-cli entirely.
+func (a *AudioAnalyzer) transcribeCppVerbose(segment AudioSegment) (string, []VerboseSegment, error) {
+	prompt := a.promptForSegment(segment)
+
+	var cacheKey string
+	if a.Cache != nil {
+		key, err := a.segmentCacheKey(segment, prompt)
+		if err == nil {
+			cacheKey = key
+			if cached, ok := a.Cache.FetchResult(cacheKey); ok {
+				a.Cache.RecordHit()
+				fmt.Println("Cache hit for transcription:", segment.Filename)
+				return cached.Text, cached.VerboseSegments, nil
+			}
+		}
+	}
+
+	text, err := a.transcribeCpp(segment)
+	if err != nil {
+		return "", nil, err
+	}
+
+	segments := parseWhisperSegments(text, segment.StartTime)
+	if a.OutputFormat == FormatVerboseJSON {
+		segments = enrichWithTokens(segments, segment.FilePath+".json")
+	}
+
+	if cacheKey != "" {
+		a.Cache.RecordMiss()
+		if err := a.Cache.StoreResult(cacheKey, cachedResult{Text: text, VerboseSegments: segments}); err != nil {
+			log.Printf("failed to cache transcription for %s: %v", segment.Filename, err)
+		}
+	}
+
+	return text, segments, nil
+}
+
 // Run executes the complete audio analysis pipeline
 func (a *AudioAnalyzer) Run() error {
 	fmt.Println("Starting audio analysis...")
@@ -349,6 +470,14 @@ func (a *AudioAnalyzer) Run() error {
 		return fmt.Errorf("failed to output results: %v", err)
 	}
 
+	totalDuration, err := a.GetAudioDuration()
+	if err != nil {
+		return fmt.Errorf("failed to get audio duration: %v", err)
+	}
+	if err := a.OutputResultsFormatted(results, a.OutputFormat, totalDuration); err != nil {
+		return fmt.Errorf("failed to output %s results: %v", a.OutputFormat, err)
+	}
+
 	fmt.Println("Audio analysis completed successfully!")
 	return nil
 }
@@ -395,21 +524,84 @@ func (a *AudioAnalyzer) OutputResults(results []TranscriptionResult) error {
 		}
 	}
 
+	if a.Cache != nil {
+		fmt.Println("\n" + a.Cache.Summary())
+	}
+
 	return nil
 }
 
+// parseFormatFlag extracts a "--format X" or "--format=X" argument from
+// args, returning the remaining positional args alongside it.
+func parseFormatFlag(args []string) ([]string, OutputFormat) {
+	format := FormatText
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "--format="):
+			format = OutputFormat(strings.TrimPrefix(arg, "--format="))
+		case arg == "--format" && i+1 < len(args):
+			format = OutputFormat(args[i+1])
+			i++
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return rest, format
+}
+
+// parseStreamFlag extracts a "--stream"/"--mic" switch from args, returning
+// the remaining positional args alongside whether it was present.
+func parseStreamFlag(args []string) ([]string, bool) {
+	stream := false
+	var rest []string
+
+	for _, arg := range args {
+		if arg == "--stream" || arg == "--mic" {
+			stream = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+
+	return rest, stream
+}
+
 func main() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: go run main.go <input_audio_file> <output_directory> [max_workers] [python_script]")
+	args, format := parseFormatFlag(os.Args[1:])
+	args, stream := parseStreamFlag(args)
+
+	if stream {
+		if len(args) < 1 {
+			fmt.Println("Usage: go run main.go <output_directory> --stream [--format txt|json|verbose_json|srt|vtt]")
+			os.Exit(1)
+		}
+
+		analyzer := NewAudioAnalyzer("", args[0])
+		analyzer.OutputFormat = format
+
+		if err := runStreamCLI(analyzer); err != nil {
+			log.Fatalf("Streaming transcription failed: %v", err)
+		}
+		return
+	}
+
+	if len(args) < 2 {
+		fmt.Println("Usage: go run main.go <input_audio_file> <output_directory> [max_workers] [python_script] [--format txt|json|verbose_json|srt|vtt]")
+		fmt.Println("       go run main.go <output_directory> --stream|--mic [--format txt|json|verbose_json|srt|vtt]")
 		fmt.Println("  max_workers: optional, number of parallel workers (default: auto-detect)")
 		fmt.Println("  python_script: optional, path to Python whisper script (default: ./whisper_transcriber.py)")
 		os.Exit(1)
 	}
 
-	inputFile := os.Args[1]
-	outputDir := os.Args[2]
+	inputFile := args[0]
+	outputDir := args[1]
 	// maxWorkerに
 	analyzer := NewAudioAnalyzer(inputFile, outputDir)
+	analyzer.OutputFormat = format
 
 	if err := analyzer.Run(); err != nil {
 		log.Fatalf("Audio analysis failed: %v", err)
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OutputFormat selects how OutputResults renders the final transcript,
+// mirroring the --output-format options of the OpenAI Whisper CLI.
+type OutputFormat string
+
+const (
+	FormatText        OutputFormat = "txt"
+	FormatJSON        OutputFormat = "json"
+	FormatVerboseJSON OutputFormat = "verbose_json"
+	FormatSRT         OutputFormat = "srt"
+	FormatVTT         OutputFormat = "vtt"
+)
+
+// WhisperToken is the shape of a single decoded token in whisper-cli.exe's
+// --output-json-full output. Nothing in this package fills one:
+// enrichWithTokens reads only token ids, through whisperJSONFull.
+type WhisperToken struct {
+	Text  string  `json:"text"`
+	Id    int     `json:"id"`
+	PLog  float64 `json:"p"`
+	Start float64 `json:"t0"`
+	End   float64 `json:"t1"`
+}
+
+// VerboseSegment matches the per-segment shape of OpenAI's verbose_json
+// response.
+type VerboseSegment struct {
+	Id               int     `json:"id"`
+	Seek             int     `json:"seek"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens"`
+	Temperature      float64 `json:"temperature"`
+	AvgLogprob       float64 `json:"avg_logprob"`
+	CompressionRatio float64 `json:"compression_ratio"`
+	NoSpeechProb     float64 `json:"no_speech_prob"`
+}
+
+// VerboseJSONResult matches the top-level shape of OpenAI's verbose_json
+// response.
+type VerboseJSONResult struct {
+	Task     string           `json:"task"`
+	Language string           `json:"language"`
+	Duration float64          `json:"duration"`
+	Text     string           `json:"text"`
+	Segments []VerboseSegment `json:"segments"`
+}
+
+// whisperTimestampLine matches whisper-cli.exe's default console/.txt
+// output: "[HH:MM:SS.mmm --> HH:MM:SS.mmm]   text"
+var whisperTimestampLine = regexp.MustCompile(`\[(\d{2}:\d{2}:\d{2}\.\d{3}) --> (\d{2}:\d{2}:\d{2}\.\d{3})\]\s*(.*)`)
+
+// parseWhisperTimestamp converts HH:MM:SS.mmm into seconds.
+func parseWhisperTimestamp(ts string) (float64, error) {
+	parts := strings.SplitN(ts, ":", 3)
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", ts)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(hours)*3600 + float64(minutes)*60 + seconds, nil
+}
+
+// parseWhisperSegments parses whisper-cli.exe's timestamped output into
+// verbose_json segments, shifting each timestamp by the containing
+// AudioSegment's start time (offset) so segment boundaries line up with the
+// original, unsplit audio.
+func parseWhisperSegments(output string, offset float64) []VerboseSegment {
+	var segments []VerboseSegment
+	for _, line := range strings.Split(output, "\n") {
+		m := whisperTimestampLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, err := parseWhisperTimestamp(m[1])
+		if err != nil {
+			continue
+		}
+		end, err := parseWhisperTimestamp(m[2])
+		if err != nil {
+			continue
+		}
+		segments = append(segments, VerboseSegment{
+			Id:    len(segments),
+			Start: offset + start,
+			End:   offset + end,
+			Text:  strings.TrimSpace(m[3]),
+		})
+	}
+	return segments
+}
+
+// whisperJSONFull is the subset of whisper-cli.exe's --output-json-full
+// schema this tool reads; the real file carries additional fields we don't
+// need.
+type whisperJSONFull struct {
+	Transcription []struct {
+		Timestamps struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		} `json:"timestamps"`
+		Offsets struct {
+			From int `json:"from"`
+			To   int `json:"to"`
+		} `json:"offsets"`
+		Text   string `json:"text"`
+		Tokens []struct {
+			Text string  `json:"text"`
+			Id   int     `json:"id"`
+			P    float64 `json:"p"`
+		} `json:"tokens"`
+	} `json:"transcription"`
+}
+
+// enrichWithTokens loads the --output-json-full sidecar file, if present,
+// and attaches token ids to the already-parsed segments.
+func enrichWithTokens(segments []VerboseSegment, jsonFullPath string) []VerboseSegment {
+	data, err := os.ReadFile(jsonFullPath)
+	if err != nil {
+		return segments
+	}
+
+	var full whisperJSONFull
+	if err := json.Unmarshal(data, &full); err != nil {
+		return segments
+	}
+
+	if len(full.Transcription) != len(segments) {
+		fmt.Printf("warning: --output-json-full sidecar %s has %d entries, expected %d; skipping token enrichment\n",
+			jsonFullPath, len(full.Transcription), len(segments))
+		return segments
+	}
+
+	for i := range segments {
+		for _, tok := range full.Transcription[i].Tokens {
+			segments[i].Tokens = append(segments[i].Tokens, tok.Id)
+		}
+	}
+	return segments
+}
+
+// BuildVerboseJSON assembles a VerboseJSONResult from the per-segment
+// transcription results produced by ProcessWithCpp/transcribeCppVerbose.
+func BuildVerboseJSON(results []TranscriptionResult, duration float64) VerboseJSONResult {
+	var all []VerboseSegment
+	var text strings.Builder
+
+	for _, r := range results {
+		if r.Error != "" {
+			continue
+		}
+		for _, seg := range r.VerboseSegments {
+			seg.Id = len(all)
+			seg.Seek = int(seg.Start * 100)
+			all = append(all, seg)
+			text.WriteString(seg.Text)
+		}
+	}
+
+	return VerboseJSONResult{
+		Task:     "transcribe",
+		Language: "ja",
+		Duration: duration,
+		Text:     text.String(),
+		Segments: all,
+	}
+}
+
+// formatSRTTimestamp renders seconds as an SRT timestamp: HH:MM:SS,mmm.
+func formatSRTTimestamp(seconds float64) string {
+	return formatClockTimestamp(seconds, ",")
+}
+
+// formatVTTTimestamp renders seconds as a WebVTT timestamp: HH:MM:SS.mmm.
+func formatVTTTimestamp(seconds float64) string {
+	return formatClockTimestamp(seconds, ".")
+}
+
+func formatClockTimestamp(seconds float64, msSep string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	totalMs := int64(seconds*1000 + 0.5)
+	ms := totalMs % 1000
+	totalSec := totalMs / 1000
+	s := totalSec % 60
+	totalMin := totalSec / 60
+	m := totalMin % 60
+	h := totalMin / 60
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, msSep, ms)
+}
+
+// writeSRT writes the parsed segments as an SRT subtitle file.
+func writeSRT(path string, segments []VerboseSegment) error {
+	var b strings.Builder
+	for i, seg := range segments {
+		fmt.Fprintf(&b, "%d\n", i+1)
+		fmt.Fprintf(&b, "%s --> %s\n", formatSRTTimestamp(seg.Start), formatSRTTimestamp(seg.End))
+		fmt.Fprintf(&b, "%s\n\n", seg.Text)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// writeVTT writes the parsed segments as a WebVTT subtitle file.
+func writeVTT(path string, segments []VerboseSegment) error {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, seg := range segments {
+		fmt.Fprintf(&b, "%s --> %s\n", formatVTTTimestamp(seg.Start), formatVTTTimestamp(seg.End))
+		fmt.Fprintf(&b, "%s\n\n", seg.Text)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// OutputResultsFormatted writes the transcription results in the requested
+// format in addition to the always-produced plain-text/JSON dump from
+// OutputResults.
+func (a *AudioAnalyzer) OutputResultsFormatted(results []TranscriptionResult, format OutputFormat, totalDuration float64) error {
+	switch format {
+	case "", FormatText, FormatJSON:
+		return nil // handled by OutputResults already
+	case FormatVerboseJSON:
+		verbose := BuildVerboseJSON(results, totalDuration)
+		data, err := json.MarshalIndent(verbose, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal verbose_json: %v", err)
+		}
+		path := filepath.Join(a.OutputDir, "transcription_results.verbose.json")
+		return os.WriteFile(path, data, 0644)
+	case FormatSRT, FormatVTT:
+		verbose := BuildVerboseJSON(results, totalDuration)
+		if format == FormatSRT {
+			return writeSRT(filepath.Join(a.OutputDir, "transcription_results.srt"), verbose.Segments)
+		}
+		return writeVTT(filepath.Join(a.OutputDir, "transcription_results.vtt"), verbose.Segments)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestParseWhisperTimestamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		ts      string
+		want    float64
+		wantErr bool
+	}{
+		{"zero", "00:00:00.000", 0, false},
+		{"seconds and ms", "00:00:01.500", 1.5, false},
+		{"minutes", "00:02:03.250", 123.25, false},
+		{"hours", "01:00:00.000", 3600, false},
+		{"malformed", "not-a-timestamp", 0, true},
+		{"missing component", "00:01", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWhisperTimestamp(tt.ts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWhisperTimestamp(%q) error = %v, wantErr %v", tt.ts, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseWhisperTimestamp(%q) = %v, want %v", tt.ts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWhisperSegments(t *testing.T) {
+	output := "[00:00:00.000 --> 00:00:01.500]   Hello\n" +
+		"some unrelated whisper.cpp log line\n" +
+		"[00:00:01.500 --> 00:00:03.000]   world\n"
+
+	segments := parseWhisperSegments(output, 10.0)
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments, got %d: %+v", len(segments), segments)
+	}
+
+	if segments[0].Start != 10.0 || segments[0].End != 11.5 || segments[0].Text != "Hello" {
+		t.Errorf("unexpected first segment: %+v", segments[0])
+	}
+	if segments[1].Id != 1 || segments[1].Start != 11.5 || segments[1].End != 13.0 || segments[1].Text != "world" {
+		t.Errorf("unexpected second segment: %+v", segments[1])
+	}
+}
+
+func TestParseWhisperSegmentsNoMatches(t *testing.T) {
+	segments := parseWhisperSegments("no timestamps here\n", 0)
+	if segments != nil {
+		t.Errorf("expected nil segments for unmatched input, got %+v", segments)
+	}
+}
+
+func TestFormatClockTimestamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds float64
+		msSep   string
+		want    string
+	}{
+		{"zero", 0, ",", "00:00:00,000"},
+		{"sub-second rounding", 1.4999, ".", "00:00:01.500"},
+		{"minutes and hours", 3661.25, ",", "01:01:01,250"},
+		{"negative clamps to zero", -5, ".", "00:00:00.000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatClockTimestamp(tt.seconds, tt.msSep); got != tt.want {
+				t.Errorf("formatClockTimestamp(%v, %q) = %q, want %q", tt.seconds, tt.msSep, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrichWithTokensMissingSidecar(t *testing.T) {
+	segments := []VerboseSegment{{Id: 0, Text: "a"}, {Id: 1, Text: "b"}}
+	got := enrichWithTokens(segments, "/nonexistent/path/does-not-exist.json")
+	if len(got) != 2 || got[0].Tokens != nil || got[1].Tokens != nil {
+		t.Errorf("expected segments returned unmodified when sidecar is unreadable, got %+v", got)
+	}
+}
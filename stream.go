@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// streamSampleRate is the capture rate required by whisper.cpp.
+const streamSampleRate = 16000
+
+// AudioCapture is a pluggable microphone capture source. Implementations
+// deliver mono 16-bit PCM samples at streamSampleRate.
+type AudioCapture interface {
+	Start() error
+	Stop() error
+	// Read blocks until at least one sample is available and fills buf,
+	// returning the number of samples written.
+	Read(buf []int16) (int, error)
+}
+
+// streamConfig controls how RunStream segments the live microphone feed.
+type streamConfig struct {
+	MinUtteranceDuration float64 // seconds
+	MaxUtteranceDuration float64 // seconds
+	SilenceHoldoff       float64 // seconds of silence that close an utterance
+	FrameSize            int     // samples per VAD frame
+}
+
+func defaultStreamConfig() streamConfig {
+	return streamConfig{
+		MinUtteranceDuration: 0.5,
+		MaxUtteranceDuration: 30.0,
+		SilenceHoldoff:       0.6,
+		FrameSize:            480, // 30ms @ 16kHz
+	}
+}
+
+// ringBuffer accumulates captured PCM samples belonging to the utterance
+// currently being assembled.
+type ringBuffer struct {
+	samples []int16
+}
+
+func (r *ringBuffer) append(frame []int16) {
+	r.samples = append(r.samples, frame...)
+}
+
+func (r *ringBuffer) reset() {
+	r.samples = r.samples[:0]
+}
+
+func (r *ringBuffer) durationSeconds() float64 {
+	return float64(len(r.samples)) / float64(streamSampleRate)
+}
+
+// frameIsSpeech applies the same amplitude threshold used by the
+// ffmpeg-based FFmpegThresholdVAD path, just evaluated on raw PCM frames instead
+// of an ffmpeg log.
+func frameIsSpeech(frame []int16, threshold int16) bool {
+	for _, s := range frame {
+		if s > threshold || s < -threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// RunStream captures microphone audio via cap, segments it on voice-activity
+// boundaries, transcribes each utterance as it closes, and publishes results
+// on the returned channel. Callers should drain the channel until it is
+// closed, which happens once ctx is cancelled and in-flight work drains.
+func (a *AudioAnalyzer) RunStream(ctx context.Context, cap AudioCapture) (<-chan TranscriptionResult, error) {
+	if err := cap.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start audio capture: %v", err)
+	}
+
+	if err := os.MkdirAll(a.OutputDir, 0755); err != nil {
+		cap.Stop()
+		return nil, fmt.Errorf("failed to create output directory: %v", err)
+	}
+
+	cfg := defaultStreamConfig()
+	segments := make(chan AudioSegment, a.MaxWorkers)
+	results := make(chan TranscriptionResult, a.MaxWorkers)
+
+	go a.captureLoop(ctx, cap, cfg, segments)
+	go a.streamWorkerPool(ctx, segments, results)
+
+	return results, nil
+}
+
+// captureLoop reads frames from cap, runs a simple amplitude-threshold VAD over
+// them, and emits an AudioSegment each time a speech run is followed by
+// SilenceHoldoff seconds of silence (or MaxUtteranceDuration is reached).
+func (a *AudioAnalyzer) captureLoop(ctx context.Context, cap AudioCapture, cfg streamConfig, out chan<- AudioSegment) {
+	defer close(out)
+	defer cap.Stop()
+
+	const threshold int16 = 1036 // roughly -30dB full scale, matching SilenceThreshold
+
+	frame := make([]int16, cfg.FrameSize)
+	buf := &ringBuffer{}
+	silence := 0.0
+	speaking := false
+	utteranceStart := time.Duration(0)
+	elapsed := time.Duration(0)
+	index := 0
+
+	flush := func() {
+		if buf.durationSeconds() < cfg.MinUtteranceDuration {
+			buf.reset()
+			return
+		}
+		seg := a.createStreamSegment(buf.samples, utteranceStart.Seconds(), index)
+		index++
+		buf.reset()
+		select {
+		case out <- seg:
+		case <-ctx.Done():
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if speaking {
+				flush()
+			}
+			return
+		default:
+		}
+
+		n, err := cap.Read(frame)
+		if err != nil {
+			if speaking {
+				flush()
+			}
+			return
+		}
+		chunk := frame[:n]
+		frameDuration := time.Duration(float64(n) / float64(streamSampleRate) * float64(time.Second))
+		elapsed += frameDuration
+
+		if frameIsSpeech(chunk, threshold) {
+			if !speaking {
+				speaking = true
+				utteranceStart = elapsed - frameDuration
+			}
+			silence = 0
+			buf.append(chunk)
+			if buf.durationSeconds() >= cfg.MaxUtteranceDuration {
+				flush()
+				speaking = false
+			}
+			continue
+		}
+
+		if speaking {
+			buf.append(chunk)
+			silence += frameDuration.Seconds()
+			if silence >= cfg.SilenceHoldoff {
+				flush()
+				speaking = false
+			}
+		}
+	}
+}
+
+// createStreamSegment writes captured PCM samples to a WAV file under
+// OutputDir and returns the resulting AudioSegment.
+func (a *AudioAnalyzer) createStreamSegment(samples []int16, start float64, index int) AudioSegment {
+	duration := float64(len(samples)) / float64(streamSampleRate)
+	filename := fmt.Sprintf("stream_%04d_%.1f-%.1f.wav", index, start, start+duration)
+	filePath := filepath.Join(a.OutputDir, filename)
+
+	if err := writeWAV(filePath, samples, streamSampleRate); err != nil {
+		fmt.Printf("failed to write stream segment %s: %v\n", filename, err)
+	}
+
+	return AudioSegment{
+		StartTime: start,
+		EndTime:   start + duration,
+		Filename:  filename,
+		FilePath:  filePath,
+		Duration:  duration,
+	}
+}
+
+// streamWorkerPool transcribes segments as they arrive, fanning out across
+// MaxWorkers goroutines like ProcessWithCpp does for the file-based pipeline.
+func (a *AudioAnalyzer) streamWorkerPool(ctx context.Context, segments <-chan AudioSegment, results chan<- TranscriptionResult) {
+	defer close(results)
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, a.MaxWorkers)
+
+	for seg := range segments {
+		wg.Add(1)
+		go func(seg AudioSegment) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			text, verboseSegments, err := a.transcribeCppVerbose(seg)
+			result := TranscriptionResult{Segment: seg}
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Text = text
+				result.VerboseSegments = verboseSegments
+			}
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+			}
+		}(seg)
+	}
+
+	wg.Wait()
+}
+
+// writeWAV writes mono 16-bit PCM samples as a WAV file.
+func writeWAV(path string, samples []int16, sampleRate int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dataSize := len(samples) * 2
+	byteRate := sampleRate * 2
+
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+dataSize))
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:24], 1) // mono
+	binary.LittleEndian.PutUint32(header[24:28], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(byteRate))
+	binary.LittleEndian.PutUint16(header[32:34], 2) // block align
+	binary.LittleEndian.PutUint16(header[34:36], 16)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(dataSize))
+
+	if _, err := f.Write(header); err != nil {
+		return err
+	}
+
+	payload := make([]byte, dataSize)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(payload[i*2:i*2+2], uint16(s))
+	}
+	_, err = f.Write(payload)
+	return err
+}
+
+// runStreamCLI drives RunStream for the --stream/--mic CLI mode: it
+// captures from the default microphone until interrupted (Ctrl+C),
+// printing each transcript as it arrives, then writes the usual
+// OutputResults files from everything captured during the run.
+func runStreamCLI(a *AudioAnalyzer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		fmt.Println("\nStopping stream...")
+		cancel()
+	}()
+
+	mic, err := NewPortAudioCapture()
+	if err != nil {
+		return err
+	}
+
+	results, err := a.RunStream(ctx, mic)
+	if err != nil {
+		return err
+	}
+
+	var all []TranscriptionResult
+	for result := range results {
+		if result.Error != "" {
+			fmt.Printf("[%.1fs-%.1fs] ERROR: %s\n", result.Segment.StartTime, result.Segment.EndTime, result.Error)
+		} else {
+			fmt.Printf("[%.1fs-%.1fs] %s\n", result.Segment.StartTime, result.Segment.EndTime, result.Text)
+		}
+		all = append(all, result)
+	}
+
+	if err := a.OutputResults(all); err != nil {
+		return err
+	}
+
+	var sessionDuration float64
+	if len(all) > 0 {
+		sessionDuration = all[len(all)-1].Segment.EndTime
+	}
+
+	return a.OutputResultsFormatted(all, a.OutputFormat, sessionDuration)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// subtitleTimestampLine matches both SRT ("00:00:01,000 --> 00:00:04,000")
+// and WebVTT ("00:00:01.000 --> 00:00:04.000") cue timing lines.
+var subtitleTimestampLine = regexp.MustCompile(`(\d{2}):(\d{2}):(\d{2})[.,](\d{3})\s*-->\s*(\d{2}):(\d{2}):(\d{2})[.,](\d{3})`)
+
+// subtitleCue is a single parsed VTT/SRT cue.
+type subtitleCue struct {
+	Start float64
+	End   float64
+	Text  string
+}
+
+// parseSubtitleTimestamp converts an "HH:MM:SS,mmm" or "HH:MM:SS.mmm"
+// regex match (4 numeric groups starting at idx) into seconds.
+func parseSubtitleTimestamp(m []string, idx int) (float64, error) {
+	h, err := strconv.Atoi(m[idx])
+	if err != nil {
+		return 0, err
+	}
+	min, err := strconv.Atoi(m[idx+1])
+	if err != nil {
+		return 0, err
+	}
+	s, err := strconv.Atoi(m[idx+2])
+	if err != nil {
+		return 0, err
+	}
+	ms, err := strconv.Atoi(m[idx+3])
+	if err != nil {
+		return 0, err
+	}
+	return float64(h)*3600 + float64(min)*60 + float64(s) + float64(ms)/1000, nil
+}
+
+// parseSubtitleCues reads a .vtt or .srt file and returns its cues in
+// order. Cue index lines (SRT) and the "WEBVTT" header are ignored.
+func parseSubtitleCues(path string) ([]subtitleCue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open subtitle file: %v", err)
+	}
+	defer f.Close()
+
+	var cues []subtitleCue
+	var current *subtitleCue
+	var textLines []string
+
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimSpace(strings.Join(textLines, " "))
+			cues = append(cues, *current)
+		}
+		current = nil
+		textLines = nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := subtitleTimestampLine.FindStringSubmatch(line); m != nil {
+			flush()
+
+			start, err := parseSubtitleTimestamp(m, 1)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cue start time: %v", err)
+			}
+			end, err := parseSubtitleTimestamp(m, 5)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cue end time: %v", err)
+			}
+			current = &subtitleCue{Start: start, End: end}
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		// Skip the WEBVTT header and SRT numeric cue index lines.
+		if current == nil && (strings.HasPrefix(line, "WEBVTT") || isNumericLine(line)) {
+			continue
+		}
+
+		if current != nil {
+			textLines = append(textLines, line)
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read subtitle file: %v", err)
+	}
+
+	return cues, nil
+}
+
+func isNumericLine(line string) bool {
+	_, err := strconv.Atoi(strings.TrimSpace(line))
+	return err == nil
+}
+
+// CreateSegmentsFromSubtitles builds AudioSegments directly from the cue
+// timecodes in an existing .vtt or .srt file, skipping ffmpeg silence
+// detection entirely. Each cue's text is carried through as PriorText so
+// transcribeCpp can use it as a --prompt hint when re-timing/refining the
+// transcript. Overlong cues are still split by splitLongSegments.
+func (a *AudioAnalyzer) CreateSegmentsFromSubtitles(vttOrSrtPath string) ([]AudioSegment, error) {
+	cues, err := parseSubtitleCues(vttOrSrtPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []AudioSegment
+	for _, cue := range cues {
+		if cue.End <= cue.Start {
+			continue
+		}
+		segment := a.createSegment(cue.Start, cue.End, len(segments))
+		segment.PriorText = cue.Text
+		segments = append(segments, segment)
+	}
+
+	return a.splitLongSegments(segments), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSubtitleTimestamp(t *testing.T) {
+	m := subtitleTimestampLine.FindStringSubmatch("00:01:02,500 --> 00:01:05,000")
+	if m == nil {
+		t.Fatal("expected subtitleTimestampLine to match")
+	}
+
+	start, err := parseSubtitleTimestamp(m, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if start != 62.5 {
+		t.Errorf("start = %v, want 62.5", start)
+	}
+
+	end, err := parseSubtitleTimestamp(m, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if end != 65.0 {
+		t.Errorf("end = %v, want 65.0", end)
+	}
+}
+
+func writeTempSubtitle(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write temp subtitle: %v", err)
+	}
+	return path
+}
+
+func TestParseSubtitleCuesVTT(t *testing.T) {
+	path := writeTempSubtitle(t, "cues.vtt", "WEBVTT\n\n"+
+		"00:00:01.000 --> 00:00:02.500\n"+
+		"Hello\n\n"+
+		"00:00:03.000 --> 00:00:04.000\n"+
+		"world\nagain\n")
+
+	cues, err := parseSubtitleCues(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cues) != 2 {
+		t.Fatalf("expected 2 cues, got %d: %+v", len(cues), cues)
+	}
+	if cues[0].Start != 1.0 || cues[0].End != 2.5 || cues[0].Text != "Hello" {
+		t.Errorf("unexpected first cue: %+v", cues[0])
+	}
+	if cues[1].Text != "world again" {
+		t.Errorf("expected multi-line cue text to be joined, got %q", cues[1].Text)
+	}
+}
+
+func TestParseSubtitleCuesSRT(t *testing.T) {
+	path := writeTempSubtitle(t, "cues.srt", "1\n"+
+		"00:00:01,000 --> 00:00:02,500\n"+
+		"Hello\n\n"+
+		"2\n"+
+		"00:00:03,000 --> 00:00:04,000\n"+
+		"world\n")
+
+	cues, err := parseSubtitleCues(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cues) != 2 {
+		t.Fatalf("expected 2 cues, got %d: %+v", len(cues), cues)
+	}
+	if cues[0].Text != "Hello" || cues[1].Text != "world" {
+		t.Errorf("unexpected cue text: %+v", cues)
+	}
+}
+
+func TestParseSubtitleCuesMissingFile(t *testing.T) {
+	if _, err := parseSubtitleCues(filepath.Join(t.TempDir(), "does-not-exist.vtt")); err == nil {
+		t.Error("expected an error for a missing subtitle file")
+	}
+}
@@ -44,3 +44,26 @@ func Clean() {
 		log.Fatalf("エラー: %v", err)
 	}
 }
+
+// CleanCache removes every entry under the segment cache directory (default
+// ./output/.cache/), for purging stale entries after a prompt or model
+// change makes them unreachable by key.
+func CleanCache(cacheDir string) error {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read cache directory: %v", err)
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(cacheDir, entry.Name())
+		fmt.Printf("削除: %s\n", path)
+		if err := os.Remove(path); err != nil {
+			log.Printf("削除失敗: %s (%v)", path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// SpeechRegion is a detected span of speech in the input audio, in seconds.
+type SpeechRegion struct {
+	Start float64
+	End   float64
+}
+
+// VoiceActivityDetector locates speech regions in an audio file. CreateSegments
+// merges the returned regions to respect MinSegmentDuration/MaxSegmentDuration.
+type VoiceActivityDetector interface {
+	DetectSpeechRegions(inputFile string) ([]SpeechRegion, error)
+}
+
+// FFmpegThresholdVAD is the original amplitude-threshold detector, backed by
+// ffmpeg's silencedetect filter.
+type FFmpegThresholdVAD struct {
+	Threshold string // ffmpegの無音検出閾値, e.g. "-30dB"
+	Duration  string // 無音継続時間 in seconds, e.g. "5"
+}
+
+var silenceStartRe = regexp.MustCompile(`silence_start:\s*([\d.]+)`)
+var silenceEndRe = regexp.MustCompile(`silence_end:\s*([\d.]+)`)
+
+// DetectSpeechRegions runs ffmpeg silencedetect and returns the complement
+// of the detected silence intervals as speech regions.
+func (v FFmpegThresholdVAD) DetectSpeechRegions(inputFile string) ([]SpeechRegion, error) {
+	cmd := exec.Command("ffmpeg",
+		"-i", inputFile,
+		"-af", fmt.Sprintf("silencedetect=noise=%s:duration=%s", v.Threshold, v.Duration),
+		"-f", "null", "-")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg silence detection failed: %v", err)
+	}
+
+	duration, err := GetAudioDuration(inputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var silences [][2]float64
+	var openStart float64
+	haveOpenStart := false
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if m := silenceStartRe.FindStringSubmatch(line); m != nil {
+			if start, err := strconv.ParseFloat(m[1], 64); err == nil {
+				openStart = start
+				haveOpenStart = true
+			}
+			continue
+		}
+		if m := silenceEndRe.FindStringSubmatch(line); m != nil {
+			if end, err := strconv.ParseFloat(m[1], 64); err == nil && haveOpenStart {
+				silences = append(silences, [2]float64{openStart, end})
+				haveOpenStart = false
+			}
+		}
+	}
+
+	return complementRegions(silences, duration), nil
+}
+
+// complementRegions turns a sorted list of silence intervals into the
+// speech regions between them, spanning [0, duration].
+func complementRegions(silences [][2]float64, duration float64) []SpeechRegion {
+	var regions []SpeechRegion
+	cursor := 0.0
+
+	for _, s := range silences {
+		if s[0] > cursor {
+			regions = append(regions, SpeechRegion{Start: cursor, End: s[0]})
+		}
+		if s[1] > cursor {
+			cursor = s[1]
+		}
+	}
+	if duration > cursor {
+		regions = append(regions, SpeechRegion{Start: cursor, End: duration})
+	}
+
+	return regions
+}
+
+// neuralVADFrameSize is 30ms of audio at 16kHz mono.
+const neuralVADFrameSize = 480
+
+// NeuralVAD decodes the input to 16kHz mono PCM via an ffmpeg pipe and
+// scores each 30ms frame with an ONNX Runtime session loading a small
+// pretrained Silero-style VAD model. Speech/non-speech is decided with
+// hysteresis: speech starts after 3 consecutive frames above EnterThreshold
+// and ends after SilenceDuration worth of frames below ExitThreshold.
+type NeuralVAD struct {
+	ModelPath       string
+	EnterThreshold  float64
+	ExitThreshold   float64
+	SilenceDuration float64 // seconds of sub-ExitThreshold audio required to end a region
+}
+
+// NewNeuralVAD returns a NeuralVAD with the hysteresis thresholds described
+// in the streaming/VAD design: enter speech at >0.5 for >=3 frames, exit
+// at <0.35 for silenceDuration seconds.
+func NewNeuralVAD(modelPath string, silenceDuration float64) *NeuralVAD {
+	return &NeuralVAD{
+		ModelPath:       modelPath,
+		EnterThreshold:  0.5,
+		ExitThreshold:   0.35,
+		SilenceDuration: silenceDuration,
+	}
+}
+
+// DetectSpeechRegions streams the input through ffmpeg, scores each frame,
+// and returns the resulting speech regions after hysteresis.
+func (v *NeuralVAD) DetectSpeechRegions(inputFile string) ([]SpeechRegion, error) {
+	if err := ort.InitializeEnvironment(); err != nil {
+		return nil, fmt.Errorf("failed to initialize onnxruntime: %v", err)
+	}
+	defer ort.DestroyEnvironment()
+
+	session, err := ort.NewDynamicAdvancedSession(v.ModelPath, []string{"input"}, []string{"output"}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load VAD model %s: %v", v.ModelPath, err)
+	}
+	defer session.Destroy()
+
+	cmd := exec.Command("ffmpeg", "-i", inputFile, "-f", "s16le", "-ar", "16000", "-ac", "1", "-")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ffmpeg pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %v", err)
+	}
+
+	var regions []SpeechRegion
+	var regionStart float64
+	inSpeech := false
+	consecutiveAbove := 0
+	silenceRun := 0.0
+	frameDuration := float64(neuralVADFrameSize) / float64(streamSampleRate)
+	elapsed := 0.0
+
+	frame := make([]int16, neuralVADFrameSize)
+	for {
+		n, readErr := readPCMFrame(stdout, frame)
+		if n > 0 {
+			prob, scoreErr := v.scoreFrame(session, frame[:n])
+			if scoreErr != nil {
+				cmd.Process.Kill()
+				cmd.Wait()
+				return nil, scoreErr
+			}
+
+			if prob > v.EnterThreshold {
+				consecutiveAbove++
+				silenceRun = 0
+				if !inSpeech && consecutiveAbove >= 3 {
+					inSpeech = true
+					regionStart = elapsed - float64(consecutiveAbove-1)*frameDuration
+				}
+			} else {
+				consecutiveAbove = 0
+				if prob < v.ExitThreshold && inSpeech {
+					silenceRun += frameDuration
+					if silenceRun >= v.SilenceDuration {
+						regions = append(regions, SpeechRegion{Start: regionStart, End: elapsed - silenceRun + frameDuration})
+						inSpeech = false
+						silenceRun = 0
+					}
+				}
+			}
+
+			elapsed += frameDuration
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	if inSpeech {
+		regions = append(regions, SpeechRegion{Start: regionStart, End: elapsed})
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg pcm decode failed: %v", err)
+	}
+
+	return regions, nil
+}
+
+// scoreFrame runs a single 30ms frame through the ONNX VAD model and
+// returns the speech probability.
+func (v *NeuralVAD) scoreFrame(session *ort.DynamicAdvancedSession, frame []int16) (float64, error) {
+	samples := make([]float32, len(frame))
+	for i, s := range frame {
+		samples[i] = float32(s) / 32768.0
+	}
+
+	input, err := ort.NewTensor(ort.NewShape(1, int64(len(samples))), samples)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build VAD input tensor: %v", err)
+	}
+	defer input.Destroy()
+
+	output, err := ort.NewEmptyTensor[float32](ort.NewShape(1, 1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build VAD output tensor: %v", err)
+	}
+	defer output.Destroy()
+
+	if err := session.Run([]ort.Value{input}, []ort.Value{output}); err != nil {
+		return 0, fmt.Errorf("VAD inference failed: %v", err)
+	}
+
+	return float64(output.GetData()[0]), nil
+}
+
+// readPCMFrame fills frame with up to len(frame) int16 samples from r,
+// returning the number of samples read.
+func readPCMFrame(r io.Reader, frame []int16) (int, error) {
+	buf := make([]byte, len(frame)*2)
+	read, err := io.ReadFull(r, buf)
+	samples := read / 2
+	for i := 0; i < samples; i++ {
+		frame[i] = int16(uint16(buf[i*2]) | uint16(buf[i*2+1])<<8)
+	}
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return samples, err
+}
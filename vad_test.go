@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComplementRegions(t *testing.T) {
+	tests := []struct {
+		name     string
+		silences [][2]float64
+		duration float64
+		want     []SpeechRegion
+	}{
+		{
+			name:     "no silence",
+			silences: nil,
+			duration: 10,
+			want:     []SpeechRegion{{Start: 0, End: 10}},
+		},
+		{
+			name:     "silence in the middle",
+			silences: [][2]float64{{4, 6}},
+			duration: 10,
+			want:     []SpeechRegion{{Start: 0, End: 4}, {Start: 6, End: 10}},
+		},
+		{
+			name:     "silence at the start",
+			silences: [][2]float64{{0, 2}},
+			duration: 10,
+			want:     []SpeechRegion{{Start: 2, End: 10}},
+		},
+		{
+			name:     "silence through the end",
+			silences: [][2]float64{{8, 10}},
+			duration: 10,
+			want:     []SpeechRegion{{Start: 0, End: 8}},
+		},
+		{
+			name:     "entirely silent",
+			silences: [][2]float64{{0, 10}},
+			duration: 10,
+			want:     nil,
+		},
+		{
+			name:     "overlapping silence intervals",
+			silences: [][2]float64{{2, 5}, {4, 7}},
+			duration: 10,
+			want:     []SpeechRegion{{Start: 0, End: 2}, {Start: 7, End: 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := complementRegions(tt.silences, tt.duration)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("complementRegions(%v, %v) = %+v, want %+v", tt.silences, tt.duration, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeSpeechRegions(t *testing.T) {
+	a := &AudioAnalyzer{MinSegmentDuration: 5, OutputDir: "out"}
+
+	t.Run("empty input", func(t *testing.T) {
+		if got := a.mergeSpeechRegions(nil); len(got) != 0 {
+			t.Errorf("expected no segments, got %+v", got)
+		}
+	})
+
+	t.Run("merges short regions up to MinSegmentDuration", func(t *testing.T) {
+		regions := []SpeechRegion{{Start: 0, End: 2}, {Start: 2, End: 6}}
+		segments := a.mergeSpeechRegions(regions)
+		if len(segments) != 1 {
+			t.Fatalf("expected 1 merged segment, got %d: %+v", len(segments), segments)
+		}
+		if segments[0].StartTime != 0 || segments[0].EndTime != 6 {
+			t.Errorf("unexpected merged bounds: %+v", segments[0])
+		}
+	})
+
+	t.Run("a trailing region below MinSegmentDuration is absorbed by the running segment", func(t *testing.T) {
+		regions := []SpeechRegion{{Start: 0, End: 10}, {Start: 10, End: 11}}
+		segments := a.mergeSpeechRegions(regions)
+		if len(segments) != 1 {
+			t.Fatalf("expected 1 segment, got %d: %+v", len(segments), segments)
+		}
+		if segments[0].EndTime != 11 {
+			t.Errorf("expected the trailing region to extend the last segment, got %+v", segments[0])
+		}
+	})
+}